@@ -1,73 +1,62 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
-	"regexp"
-	"sort"
+	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/emanuelbust/autocomplete/corpus"
+	"github.com/emanuelbust/autocomplete/ngram"
+	"github.com/emanuelbust/autocomplete/prefixindex"
+	"github.com/emanuelbust/autocomplete/tokenize"
 )
 
-// WordCount This comment is only here to satisfy Visual Studio Code
-/*
-	WordCount serves as a tuple for a word and how many times it's counted.
-	Ie the WordCount WordCount{"donkey", 12} means "donkey" appeared 12 times
-*/
-type WordCount struct {
-	Word  string
-	Count int
-}
+// sseBatchSize is how many matches are sent per Server-Sent Events frame
+// when streaming a response, instead of one frame per match.
+const sseBatchSize = 5
 
 /*
 	Purpose
-	Takes a list of all of words, iterates through them, and then stores that
-	information in a map. This function is used to count all of the words in
-	file containing Shakespeare's works.
+	Takes a list of all of words and inserts each one into the given trie
+	with a delta of 1, building up the frequency of every word. This
+	function is used to index all of the words in a corpus file.
 
 	Parameters
-	The list of words to count
-
-	Returns
-	A map of the form x -> y where the word x appeared y times in the given
-	list of words
+	trie  - the prefix index to populate
+	words - the list of words to count
 */
-func countWords(words []string) map[string]int {
-	frequencyMap := make(map[string]int)
-
+func countWords(trie *prefixindex.Trie, words []string) {
 	for _, word := range words {
-		count, exists := frequencyMap[word]
-
-		// We've seen the word and increase the count
-		if exists {
-			frequencyMap[word] = count + 1
-			// The word's new and we have to start counting
-		} else {
-			frequencyMap[word] = 1
-		}
-
+		trie.Insert(word, 1)
 	}
-
-	return frequencyMap
 }
 
 /*
 	Purpose
-	Takes a file path, reads the contents of the, gets rid of non alphabetic
-	characters, normalizes the text by decapitalizing all of its characters,
-	and then parses all of the words. The parsing is done using regex. This
-	function is used to parse the file of Shakespeare's works.
+	Takes a file path, reads the contents of it, and tokenizes them with the
+	given tokenizer. Tokenization is Unicode-aware, so non-ASCII corpora
+	(accented Latin script, Cyrillic, CJK, ...) are parsed correctly, and
+	applies whatever stemming/stopword/min-length rules the tokenizer was
+	configured with.
 
 	Parameters
-	The path to a file relative to the working directory
+	path      - the path to a file relative to the working directory
+	tokenizer - the tokenizer used to split and normalize the file's text
 
 	Returns
 	A list of the words in the file given
 */
-func parseFile(path string) []string {
+func parseFile(path string, tokenizer tokenize.Tokenizer) []string {
 	// Try to read the file
 	bytes, readError := ioutil.ReadFile(path)
 	if readError != nil {
@@ -76,168 +65,525 @@ func parseFile(path string) []string {
 	fileAsString := string(bytes)
 	log.Printf("Successfully read file")
 
-	// Filter out non alphabetic characters
-	nonAlphabeticRegex := regexp.MustCompile("[^a-zA-Z\\s]")
-	fileAlpha := nonAlphabeticRegex.ReplaceAllString(fileAsString, "")
-	log.Printf("Successfully filtered file")
-
-	// Make everything lowercase
-	fileAlpha = strings.ToLower(fileAlpha)
-
-	// Parse into words
-	whitespaceRegex := regexp.MustCompile("\\s+")
-	fileAsWords := whitespaceRegex.Split(fileAlpha, -1)
-	log.Printf("Successfully parsered file")
+	fileAsWords := tokenizer.Tokenize(fileAsString)
+	log.Printf("Successfully parsed file")
 
 	return fileAsWords
 }
 
 /*
 	Purpose
-	Takes a prefix and completes the word with the most likely words. The
-	completion is done by finding the most frequently used words in the given
-	frequency map, and then putting them into a list where the most frequently
-	used words come first
+	Handles the api part of the program. Given the expected request, the top 25
+	best autcompletions will be returned given an error doesn't happen. If the
+	request isn't as expected, a message will be returned. An optional
+	?corpus= query parameter restricts the search to a single loaded corpus;
+	without it, completions are merged across every loaded corpus. An
+	optional ?context= query parameter ranks completions by how likely they
+	are to follow that trailing text, interpolating unigram/bigram/trigram
+	probability, instead of by raw frequency. An optional ?fuzzy=N query
+	parameter switches to typo-tolerant matching, returning terms within
+	edit distance N of term instead of requiring an exact prefix match;
+	?transpose=true additionally counts an adjacent-rune swap as a single
+	edit instead of two.
+
+	If the client sends "Accept: text/event-stream", the response is
+	streamed as Server-Sent Events instead: matches are pushed in batches
+	ordered by descending frequency, terminated by an "event: done" frame.
+	Streaming checks r.Context() between batches, and that context is
+	threaded through the ranking walk itself, so a client disconnect aborts
+	the work early rather than always computing the full result. Clients
+	that don't ask for it get the same JSON response as before.
 
-	Parameters
-	prfix - the beginning of the word. Ie "th"
-	frequencies - a map of the form x -> where the word x occurs y times in the
-				  training data used to complete the word
+	Thanks: https://tutorialedge.net/golang/creating-restful-api-with-golang/
 
-	Returns
-	A list of strings. These strings all begin with the given prefix. The
-	strings appear in the how frequently they were used. Ie the first string
-	was used most often and the last string was used least often.
+	Parameters
+		w        - the response write
+		r        - the request
+		registry - the corpora to search for completions
 */
-func complete(prefix string, frequencies map[string]int) []string {
+func respond(w http.ResponseWriter, r *http.Request, registry *corpus.Registry) {
+	log.Println("Entering response handler...")
 
-	// Find the words that match and how many times they occur
-	wordCounts := make([]WordCount, 0)
+	// Get the url
+	url, err := url.Parse(r.RequestURI)
+	if err != nil {
+		panic(err)
+	}
+
+	// Validate the request
+	term := tokenizer.NormalizeTerm(r.URL.Query().Get("term"))
+	corpusName := r.URL.Query().Get("corpus")
+	context := contextWords(r.URL.Query().Get("context"))
+	fuzzyDist, isFuzzy := -1, false
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("fuzzy")); err == nil && parsed >= 0 {
+		fuzzyDist, isFuzzy = parsed, true
+	}
+	transpose := r.URL.Query().Get("transpose") == "true"
+	supportedMethod := r.Method == "GET"
+	validEndpoint := url.Path == "/autocomplete"
+	includedTerm := term != ""
+	validRequest := supportedMethod && validEndpoint && includedTerm
 
-	for word, count := range frequencies {
-		if strings.HasPrefix(word, prefix) {
-			wordCounts = append(wordCounts, WordCount{Word: word, Count: count})
+	if !validRequest {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": "Unsupported request"}`))
+		return
+	}
+
+	ctx := r.Context()
+	var matches []string
+	if isFuzzy {
+		fuzzyMatches, found := registry.Fuzzy(ctx, corpusName, term, fuzzyDist, transpose, 25)
+		if !found {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "Unknown corpus"}`))
+			return
+		}
+		matches = make([]string, len(fuzzyMatches))
+		for i, match := range fuzzyMatches {
+			matches[i] = match.Word
+		}
+	} else {
+		completions, found := registry.Complete(ctx, corpusName, term, context, 25, lambdas)
+		if !found {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "Unknown corpus"}`))
+			return
+		}
+		matches = make([]string, len(completions))
+		for i, completion := range completions {
+			matches[i] = completion.Word
 		}
 	}
+	log.Printf("Prefix: %s Corpus: %q Context: %v Fuzzy: %v Matches: %v", term, corpusName, context, isFuzzy, matches)
 
-	// Sort the matches in decreasing order
-	sort.SliceStable(wordCounts, func(i, j int) bool {
-		return !(wordCounts[i].Count < wordCounts[j].Count)
-	})
-	log.Println("# of matches: ", len(wordCounts))
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		streamMatches(w, ctx, matches)
+		return
+	}
 
-	// Convert the matches into a list of strings
-	matches := make([]string, len(wordCounts))
-	for i := 0; i < len(wordCounts); i++ {
-		matches[i] = wordCounts[i].Word
+	matchesJSON, error := json.Marshal(matches)
+	if error != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message": "Internal service error"}`))
+		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"matches":` + string(matchesJSON) + `}`))
+}
+
+// streamMatches writes matches to w as Server-Sent Events, sseBatchSize at a
+// time, in the order given (callers pass them ordered by descending
+// frequency). It flushes after every batch and stops early if ctx is
+// canceled, i.e. the client disconnected, without sending the final "done"
+// event. w is expected to implement http.Flusher; if it doesn't, writes
+// still succeed, they just arrive all at once when the handler returns.
+func streamMatches(w http.ResponseWriter, ctx context.Context, matches []string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	for start := 0; start < len(matches); start += sseBatchSize {
+		if ctx.Err() != nil {
+			return
+		}
+
+		end := start + sseBatchSize
+		if end > len(matches) {
+			end = len(matches)
+		}
 
-	return matches
+		batchJSON, err := json.Marshal(matches[start:end])
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "event: matches\ndata: {\"matches\":%s}\n\n", batchJSON)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+	fmt.Fprint(w, "event: done\ndata: {}\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
 }
 
 /*
 	Purpose
-	Takes the first n strings of a list. If n is larger than the amount of
-	elements of the list, then the original list is returned.
+	Handles runtime vocabulary growth. A POST to /learn?term=...&corpus=...
+	increments the term's frequency in the named corpus by one, so the
+	index can grow after startup without re-parsing the whole corpus. The
+	?corpus= parameter may be omitted only if the registry holds exactly
+	one corpus.
 
 	Parameters
-	The list to take the first n of. Assuming this is positive
+		w        - the response writer
+		r        - the request
+		registry - the corpora to update
+*/
+func learn(w http.ResponseWriter, r *http.Request, registry *corpus.Registry) {
+	log.Println("Entering learn handler...")
 
-	Returns
-	The first n elements in the list or the entire list if n > len(n)
+	url, err := url.Parse(r.RequestURI)
+	if err != nil {
+		panic(err)
+	}
 
-*/
-func firstN(words []string, n int) []string {
-	// Filter to the top n hits if needed
-	if n < len(words) {
-		return words[:n]
+	// Validate the request
+	term := tokenizer.NormalizeTerm(r.URL.Query().Get("term"))
+	corpusName := r.URL.Query().Get("corpus")
+	supportedMethod := r.Method == "POST"
+	validEndpoint := url.Path == "/learn"
+	includedTerm := term != ""
+	validRequest := supportedMethod && validEndpoint && includedTerm
+
+	w.Header().Set("Content-Type", "application/json")
+	if !validRequest {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": "Unsupported request"}`))
+		return
 	}
 
-	return words
+	if corpusName == "" {
+		if sole, ok := registry.SoleCorpus(); ok {
+			corpusName = sole
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "corpus is required when more than one corpus is loaded"}`))
+			return
+		}
+	}
+
+	if !registry.Learn(corpusName, term, 1) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": "Unknown corpus"}`))
+		return
+	}
+
+	log.Printf("Learned term: %s Corpus: %q", term, corpusName)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "ok"}`))
 }
 
 /*
 	Purpose
-	Handles the api part of the program. Given the expected request, the top 25
-	best autcompletions will be returned given an error doesn't happen. If the
-	request isn't as expected, a message will be returned
-
-	Thanks: https://tutorialedge.net/golang/creating-restful-api-with-golang/
+	Handles GET /predict?context=...&k=...&corpus=..., returning the top k
+	words most likely to follow context regardless of any prefix, ranked
+	by Jelinek-Mercer interpolated unigram/bigram/trigram probability. k
+	defaults to 10 if omitted or invalid.
 
 	Parameters
-		w - the response write
-		r - the request
+		w        - the response writer
+		r        - the request
+		registry - the corpora to search for predictions
 */
-func respond(w http.ResponseWriter, r *http.Request) {
-	log.Println("Entering response handler...")
+func predict(w http.ResponseWriter, r *http.Request, registry *corpus.Registry) {
+	log.Println("Entering predict handler...")
 
-	// Get the url
 	url, err := url.Parse(r.RequestURI)
 	if err != nil {
 		panic(err)
 	}
 
-	// Validate the request
-	term := r.URL.Query().Get("term")
+	context := contextWords(r.URL.Query().Get("context"))
+	corpusName := r.URL.Query().Get("corpus")
+	k := 10
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("k")); err == nil && parsed > 0 {
+		k = parsed
+	}
+
 	supportedMethod := r.Method == "GET"
-	validEndpoint := url.Path == "/autocomplete"
-	includedTerm := term != ""
-	validRequest := supportedMethod && validEndpoint && includedTerm
+	validEndpoint := url.Path == "/predict"
+	includedContext := len(context) > 0
+	validRequest := supportedMethod && validEndpoint && includedContext
 
-	// Respond
 	w.Header().Set("Content-Type", "application/json")
-	if validRequest {
-		// Find the matches, take the 1st 25, and derialize
-		matches := complete(term, countMap)
-		top25 := firstN(matches, 25)
-		top25json, error := json.Marshal(top25)
-
-		log.Printf("Prefix: %s Matches: %v", term, top25)
-
-		// Try to respond
-		if error != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(`{"message": "Internal service error"}`))
-		} else {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"matches":` + string(top25json) + `}`))
-		}
-	} else {
+	if !validRequest {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(`{"message": "Unsupported request"}`))
+		return
+	}
+
+	completions, found := registry.Predict(corpusName, context, k, lambdas)
+	if !found {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": "Unknown corpus"}`))
+		return
+	}
+
+	predictions := make([]string, len(completions))
+	for i, completion := range completions {
+		predictions[i] = completion.Word
+	}
+	log.Printf("Context: %v Corpus: %q Predictions: %v", context, corpusName, predictions)
 
+	predictionsJSON, error := json.Marshal(predictions)
+	if error != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message": "Internal service error"}`))
+		return
 	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"predictions":` + string(predictionsJSON) + `}`))
 }
 
 /*
 	Purpose
-	Initializes the frequency map used to complete words. If the process of
-	initialization, thie function must also read and parse the input file
-	given via the command line
+	Handles GET /corpora, listing the name and merge weight of every corpus
+	the server has loaded.
+
+	Parameters
+		w        - the response writer
+		r        - the request
+		registry - the corpora to list
 */
-func initCounts() map[string]int {
-	// Access the input file via command line
-	if len(os.Args) < 2 {
-		log.Println("Please include a file path as the 1st cmd line argument")
-		os.Exit(1)
+func corpora(w http.ResponseWriter, r *http.Request, registry *corpus.Registry) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": "Unsupported request"}`))
+		return
 	}
 
-	// Read the file path
-	filePath := os.Args[1]
-	log.Println("Data file path: ", filePath)
+	infosJSON, err := json.Marshal(registry.List())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message": "Internal service error"}`))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"corpora":` + string(infosJSON) + `}`))
+}
+
+var (
+	stemFlag      = flag.Bool("stem", false, "apply suffix stemming to tokens")
+	stopwordsFlag = flag.String("stopwords", "", "path to a newline-delimited stopword list")
+	minLengthFlag = flag.Int("min-length", 1, "minimum token length, in runes, to index")
+
+	corpusDirFlag = flag.String("corpus-dir", "", "glob of files to load as corpora, one per file named after its base filename")
 
-	// Parse the file
-	fileAsWords := parseFile(filePath)
+	corpusFlag       nameValueFlags
+	corpusWeightFlag nameValueFlags
 
-	return countWords(fileAsWords)
+	lambdaUnigramFlag = flag.Float64("lambda-unigram", ngram.DefaultLambdas().Unigram, "unigram weight for context-ranked completions/predictions; must sum with the other lambdas to 1")
+	lambdaBigramFlag  = flag.Float64("lambda-bigram", ngram.DefaultLambdas().Bigram, "bigram weight for context-ranked completions/predictions; must sum with the other lambdas to 1")
+	lambdaTrigramFlag = flag.Float64("lambda-trigram", ngram.DefaultLambdas().Trigram, "trigram weight for context-ranked completions/predictions; must sum with the other lambdas to 1")
+)
+
+// nameValueFlags collects repeated name=value command line flags, e.g.
+// multiple --corpus name=path occurrences.
+type nameValueFlags []string
+
+func (f *nameValueFlags) String() string {
+	return strings.Join(*f, ",")
 }
 
-// Each word and it's count in the file passed on startup
-var countMap = initCounts()
+func (f *nameValueFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// splitNameValue splits a "name=value" flag argument in two.
+func splitNameValue(spec string) (name string, value string, ok bool) {
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+/*
+	Purpose
+	Initializes the tokenizer used to parse corpora and normalize query
+	terms. This is also where every command line flag is registered and
+	parsed, since it's the first initializer main calls that needs flag
+	values, the --corpus/--corpus-weight flags are repeatable
+	and so must be registered with flag.Var before flag.Parse runs.
+*/
+func initTokenizer() tokenize.Tokenizer {
+	flag.Var(&corpusFlag, "corpus", "a name=path corpus to load; may be repeated")
+	flag.Var(&corpusWeightFlag, "corpus-weight", "a name=weight override used when merging corpora; may be repeated")
+	flag.Parse()
+
+	var stopwords map[string]bool
+	if *stopwordsFlag != "" {
+		loaded, err := tokenize.LoadStopwords(*stopwordsFlag)
+		if err != nil {
+			log.Fatalf("Failed to load stopwords from %s: %v", *stopwordsFlag, err)
+		}
+		stopwords = loaded
+	}
+
+	return tokenize.New(tokenize.Config{
+		Stem:      *stemFlag,
+		Stopwords: stopwords,
+		MinLength: *minLengthFlag,
+	})
+}
+
+// Normalizes corpora and incoming query terms according to the --stem,
+// --stopwords and --min-length flags. Set by main before the server starts;
+// left unset (rather than initialized inline) so tests can load this
+// package without triggering flag parsing.
+var tokenizer tokenize.Tokenizer
+
+// contextWords tokenizes a raw ?context= query value the same way a corpus
+// is tokenized, so context words are matched against the n-gram tables in
+// the same normalized form they were indexed in.
+func contextWords(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	return tokenizer.Tokenize(raw)
+}
+
+/*
+	Purpose
+	Initializes the Jelinek-Mercer interpolation weights from the
+	--lambda-unigram, --lambda-bigram and --lambda-trigram flags, which
+	must sum to 1.
+*/
+func initLambdas() ngram.Lambdas {
+	lambdas := ngram.Lambdas{
+		Unigram: *lambdaUnigramFlag,
+		Bigram:  *lambdaBigramFlag,
+		Trigram: *lambdaTrigramFlag,
+	}
+
+	sum := lambdas.Unigram + lambdas.Bigram + lambdas.Trigram
+	if math.Abs(sum-1) > 0.001 {
+		log.Fatalf("--lambda-unigram, --lambda-bigram and --lambda-trigram must sum to 1, got %v", sum)
+	}
+
+	return lambdas
+}
+
+// The interpolation weights used to rank context-aware completions and
+// predictions. Set by main; see the tokenizer var for why this isn't
+// initialized inline.
+var lambdas ngram.Lambdas
+
+// corpusWeights parses the --corpus-weight name=weight flags into a map.
+func corpusWeights() map[string]float64 {
+	weights := make(map[string]float64, len(corpusWeightFlag))
+	for _, spec := range corpusWeightFlag {
+		name, value, ok := splitNameValue(spec)
+		if !ok {
+			log.Fatalf("--corpus-weight expects name=weight, got %q", spec)
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.Fatalf("--corpus-weight expects a numeric weight, got %q: %v", spec, err)
+		}
+		weights[name] = weight
+	}
+	return weights
+}
+
+// loadCorpus parses path with tokenizer and registers it under name with
+// its configured weight, building both the prefix trie and the n-gram
+// model used for context-aware ranking.
+func loadCorpus(registry *corpus.Registry, name string, path string, weights map[string]float64) {
+	log.Println("Loading corpus: ", name, " from ", path)
+
+	fileAsWords := parseFile(path, tokenizer)
+
+	trie := prefixindex.New()
+	countWords(trie, fileAsWords)
+
+	ngrams := ngram.New()
+	ngrams.Add(fileAsWords)
+
+	weight, ok := weights[name]
+	if !ok {
+		weight = corpus.DefaultWeight
+	}
+	registry.Add(name, trie, ngrams, weight)
+}
+
+// corpusNameFromPath derives a corpus name from a --corpus-dir match: its
+// base filename without extension.
+func corpusNameFromPath(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+/*
+	Purpose
+	Initializes the registry of corpora used to complete words, loading
+	every corpus named by --corpus, every file matched by --corpus-dir, and
+	falling back to the legacy single file path command line argument if
+	neither flag was given.
+*/
+func initRegistry() *corpus.Registry {
+	registry := corpus.NewRegistry()
+	weights := corpusWeights()
+	loadedAny := false
+
+	for _, spec := range corpusFlag {
+		name, path, ok := splitNameValue(spec)
+		if !ok {
+			log.Fatalf("--corpus expects name=path, got %q", spec)
+		}
+		loadCorpus(registry, name, path, weights)
+		loadedAny = true
+	}
+
+	if *corpusDirFlag != "" {
+		matches, err := filepath.Glob(*corpusDirFlag)
+		if err != nil {
+			log.Fatalf("Invalid --corpus-dir glob %q: %v", *corpusDirFlag, err)
+		}
+		for _, path := range matches {
+			loadCorpus(registry, corpusNameFromPath(path), path, weights)
+			loadedAny = true
+		}
+	}
+
+	if !loadedAny {
+		if len(flag.Args()) < 1 {
+			log.Println("Please include a file path as the 1st cmd line argument, or use --corpus/--corpus-dir")
+			os.Exit(1)
+		}
+		loadCorpus(registry, "default", flag.Arg(0), weights)
+	}
+
+	return registry
+}
+
+// The corpora available to complete words, also mutated at runtime by the
+// /learn endpoint. Set by main; see the tokenizer var for why this isn't
+// initialized inline.
+var registry *corpus.Registry
 
 func main() {
+	tokenizer = initTokenizer()
+	lambdas = initLambdas()
+	registry = initRegistry()
+
 	log.Println("Starting server...")
-	http.HandleFunc("/", respond)
+	http.HandleFunc("/autocomplete", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, r, registry)
+	})
+	http.HandleFunc("/learn", func(w http.ResponseWriter, r *http.Request) {
+		learn(w, r, registry)
+	})
+	http.HandleFunc("/predict", func(w http.ResponseWriter, r *http.Request) {
+		predict(w, r, registry)
+	})
+	http.HandleFunc("/corpora", func(w http.ResponseWriter, r *http.Request) {
+		corpora(w, r, registry)
+	})
 	log.Fatal(http.ListenAndServe(":9000", nil))
 	log.Println("Stopping server...")
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// cancelingRecorder wraps an httptest.ResponseRecorder and cancels cancel
+// the first time Flush is called, simulating a client that disconnects
+// right after receiving the first SSE frame.
+type cancelingRecorder struct {
+	*httptest.ResponseRecorder
+	cancel  context.CancelFunc
+	flushes int
+}
+
+func (w *cancelingRecorder) Flush() {
+	w.flushes++
+	if w.flushes == 1 {
+		w.cancel()
+	}
+}
+
+func TestStreamMatchesStopsOnCanceledContext(t *testing.T) {
+	matches := make([]string, 2*sseBatchSize+2)
+	for i := range matches {
+		matches[i] = "word"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := &cancelingRecorder{ResponseRecorder: httptest.NewRecorder(), cancel: cancel}
+
+	streamMatches(rec, ctx, matches)
+
+	body := rec.Body.String()
+	if got := strings.Count(body, "event: matches"); got != 1 {
+		t.Errorf("got %d \"event: matches\" frames, want 1 (streaming should stop once canceled)", got)
+	}
+	if strings.Contains(body, "event: done") {
+		t.Errorf("body contains a \"done\" frame, want none since the context was canceled mid-stream: %q", body)
+	}
+}
+
+func TestStreamMatchesSendsDoneWhenUncanceled(t *testing.T) {
+	matches := []string{"a", "b", "c"}
+	rec := httptest.NewRecorder()
+
+	streamMatches(rec, context.Background(), matches)
+
+	body := rec.Body.String()
+	if got := strings.Count(body, "event: matches"); got != 1 {
+		t.Errorf("got %d \"event: matches\" frames, want 1", got)
+	}
+	if !strings.Contains(body, "event: done") {
+		t.Errorf("body missing \"done\" frame for an uncanceled stream: %q", body)
+	}
+}
@@ -0,0 +1,398 @@
+// Package corpus owns the set of named corpora an autocomplete server has
+// loaded, each backed by its own prefixindex.Trie and ngram.Model, and
+// knows how to rank and merge completions across all of them.
+package corpus
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/emanuelbust/autocomplete/ngram"
+	"github.com/emanuelbust/autocomplete/prefixindex"
+)
+
+// DefaultWeight is the weight callers should pass to Add for a corpus that
+// wasn't given an explicit weight. Add itself doesn't default a weight of 0,
+// since 0 is a legitimate choice: it excludes a corpus from merged results
+// while still letting it be searched directly via its name.
+const DefaultWeight = 1.0
+
+/*
+	Purpose
+	Info describes a loaded corpus for the /corpora listing endpoint.
+*/
+type Info struct {
+	Name   string
+	Weight float64
+}
+
+/*
+	Purpose
+	Completion pairs a candidate word with the score it was ranked by: a
+	raw (possibly weight-adjusted) frequency when no context was given, or
+	a Jelinek-Mercer interpolated n-gram score when it was.
+*/
+type Completion struct {
+	Word  string
+	Score float64
+}
+
+// entry pairs a corpus's trie and n-gram model with the weight it
+// contributes when merging results across corpora.
+type entry struct {
+	trie   *prefixindex.Trie
+	ngrams *ngram.Model
+	weight float64
+}
+
+/*
+	Purpose
+	Registry owns the named corpora an autocomplete server has loaded. It
+	replaces the old package-level frequency map global so that handlers
+	take their data explicitly and tests can build isolated registries.
+
+	Registry is safe for concurrent use.
+*/
+type Registry struct {
+	mu      sync.RWMutex
+	corpora map[string]*entry
+	order   []string
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{corpora: make(map[string]*entry)}
+}
+
+/*
+	Purpose
+	Add registers a corpus under name, backed by trie and ngrams,
+	contributing weight when its completions are merged with other
+	corpora. weight is stored as given, including 0, which excludes the
+	corpus from merged results without preventing it from being searched
+	directly; callers that want a default should pass DefaultWeight
+	explicitly rather than relying on Add to supply one. Adding a name
+	that already exists replaces it.
+
+	Parameters
+	name   - the corpus's name, as used in the ?corpus= query parameter
+	trie   - the corpus's prefix index
+	ngrams - the corpus's n-gram model, used when a ?context= is given
+	weight - the corpus's contribution when merging across corpora
+*/
+func (r *Registry) Add(name string, trie *prefixindex.Trie, ngrams *ngram.Model, weight float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.corpora[name]; !exists {
+		r.order = append(r.order, name)
+	}
+	r.corpora[name] = &entry{trie: trie, ngrams: ngrams, weight: weight}
+}
+
+/*
+	Purpose
+	Complete returns the top k completions for term. With no context,
+	candidates are ranked by raw frequency; with context, they're ranked by
+	a Jelinek-Mercer interpolation of unigram/bigram/trigram probability
+	given that trailing context. If corpusName is empty, completions are
+	merged across every loaded corpus, weighted by each corpus's
+	configured weight; otherwise only that corpus is searched.
+
+	Parameters
+	ctx        - governs early cancellation of the underlying trie walk
+	corpusName - the corpus to search, or "" to merge all of them
+	term       - the prefix to complete
+	context    - the words preceding term, oldest first, or nil for none
+	k          - the maximum number of completions to return
+	lambdas    - the interpolation weights used when context is non-empty
+
+	Returns
+	The matching completions, highest score first, and false if corpusName
+	was given but no such corpus is loaded.
+*/
+func (r *Registry) Complete(ctx context.Context, corpusName string, term string, context []string, k int, lambdas ngram.Lambdas) ([]Completion, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if corpusName == "" {
+		return r.mergedComplete(ctx, term, context, k, lambdas), true
+	}
+
+	c, ok := r.corpora[corpusName]
+	if !ok {
+		return nil, false
+	}
+	return completeOne(ctx, c, term, context, k, lambdas), true
+}
+
+// completeOne ranks a single corpus's matches for term, by raw frequency
+// when context is empty or by interpolated n-gram score otherwise.
+func completeOne(ctx context.Context, c *entry, term string, context []string, k int, lambdas ngram.Lambdas) []Completion {
+	if len(context) == 0 {
+		wordCounts := c.trie.TopK(ctx, term, k)
+		completions := make([]Completion, len(wordCounts))
+		for i, wc := range wordCounts {
+			completions[i] = Completion{Word: wc.Word, Score: float64(wc.Count)}
+		}
+		return completions
+	}
+
+	// Scoring by context needs every matching word, not just the trie's
+	// cached top-k, since a low-frequency word can still be the most
+	// likely continuation of the given context.
+	matches := c.trie.Prefix(ctx, term)
+	completions := make([]Completion, len(matches))
+	for i, wc := range matches {
+		completions[i] = Completion{Word: wc.Word, Score: c.ngrams.Score(wc.Word, context, lambdas)}
+	}
+	sortCompletions(completions)
+	if k < len(completions) {
+		completions = completions[:k]
+	}
+	return completions
+}
+
+// mergedComplete walks every corpus's full matching subtree (not just its
+// cached top-k) since a low-score word in a heavily-weighted corpus can
+// outrank a high-score word in a lightly-weighted one. It checks ctx between
+// corpora so a canceled request doesn't keep walking the remaining ones.
+func (r *Registry) mergedComplete(ctx context.Context, term string, context []string, k int, lambdas ngram.Lambdas) []Completion {
+	scores := make(map[string]float64)
+
+	for _, c := range r.corpora {
+		if ctx.Err() != nil {
+			break
+		}
+		for _, wc := range c.trie.Prefix(ctx, term) {
+			var score float64
+			if len(context) == 0 {
+				score = float64(wc.Count)
+			} else {
+				score = c.ngrams.Score(wc.Word, context, lambdas)
+			}
+			scores[wc.Word] += score * c.weight
+		}
+	}
+
+	return topCompletions(scores, k)
+}
+
+/*
+	Purpose
+	Predict returns the top k words that could follow context, regardless
+	of any prefix, ranked by Jelinek-Mercer interpolated score. If
+	corpusName is empty, predictions are merged across every loaded
+	corpus, weighted by each corpus's configured weight.
+
+	Parameters
+	corpusName - the corpus to search, or "" to merge all of them
+	context    - the words preceding the predicted word, oldest first
+	k          - the maximum number of predictions to return
+	lambdas    - the interpolation weights
+
+	Returns
+	The predicted completions, highest score first, and false if
+	corpusName was given but no such corpus is loaded.
+*/
+func (r *Registry) Predict(corpusName string, context []string, k int, lambdas ngram.Lambdas) ([]Completion, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if corpusName != "" {
+		c, ok := r.corpora[corpusName]
+		if !ok {
+			return nil, false
+		}
+		scored := c.ngrams.TopK(context, k, lambdas)
+		completions := make([]Completion, len(scored))
+		for i, s := range scored {
+			completions[i] = Completion{Word: s.Word, Score: s.Score}
+		}
+		return completions, true
+	}
+
+	scores := make(map[string]float64)
+	for _, c := range r.corpora {
+		for word, score := range c.ngrams.Scores(context, lambdas) {
+			scores[word] += score * c.weight
+		}
+	}
+	return topCompletions(scores, k), true
+}
+
+// topCompletions sorts a word->score map into descending Completions and
+// truncates it to k entries.
+func topCompletions(scores map[string]float64, k int) []Completion {
+	completions := make([]Completion, 0, len(scores))
+	for word, score := range scores {
+		completions = append(completions, Completion{Word: word, Score: score})
+	}
+	sortCompletions(completions)
+	if k < len(completions) {
+		completions = completions[:k]
+	}
+	return completions
+}
+
+// sortCompletions orders completions by descending score, breaking ties
+// alphabetically so results are deterministic.
+func sortCompletions(completions []Completion) {
+	sort.Slice(completions, func(i, j int) bool {
+		if completions[i].Score != completions[j].Score {
+			return completions[i].Score > completions[j].Score
+		}
+		return strings.Compare(completions[i].Word, completions[j].Word) < 0
+	})
+}
+
+/*
+	Purpose
+	FuzzyMatch pairs a candidate word with its edit distance from the query
+	and the (possibly weight-adjusted) frequency it was ranked by.
+*/
+type FuzzyMatch struct {
+	Word     string
+	Count    float64
+	Distance int
+}
+
+/*
+	Purpose
+	Fuzzy returns the top k words within maxDist edits of term, ranked by
+	ascending edit distance and then descending frequency. If corpusName is
+	empty, matches are merged across every loaded corpus, weighted by each
+	corpus's configured weight; otherwise only that corpus is searched.
+
+	Parameters
+	ctx        - governs early cancellation of the underlying trie walk
+	corpusName - the corpus to search, or "" to merge all of them
+	term       - the (possibly misspelled) term to match against
+	maxDist    - the maximum edit distance to allow
+	transpose  - whether adjacent-rune swaps count as one edit instead of two
+	k          - the maximum number of matches to return
+
+	Returns
+	The matching words, best match first, and false if corpusName was given
+	but no such corpus is loaded.
+*/
+func (r *Registry) Fuzzy(ctx context.Context, corpusName string, term string, maxDist int, transpose bool, k int) ([]FuzzyMatch, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if corpusName != "" {
+		c, ok := r.corpora[corpusName]
+		if !ok {
+			return nil, false
+		}
+		matches := make([]FuzzyMatch, 0)
+		for _, fm := range c.trie.Fuzzy(ctx, term, maxDist, transpose) {
+			matches = append(matches, FuzzyMatch{Word: fm.Word, Count: float64(fm.Count), Distance: fm.Distance})
+		}
+		return rankFuzzy(matches, k), true
+	}
+
+	best := make(map[string]FuzzyMatch)
+	for _, c := range r.corpora {
+		if ctx.Err() != nil {
+			break
+		}
+		for _, fm := range c.trie.Fuzzy(ctx, term, maxDist, transpose) {
+			count := float64(fm.Count) * c.weight
+			if existing, ok := best[fm.Word]; !ok || fm.Distance < existing.Distance {
+				best[fm.Word] = FuzzyMatch{Word: fm.Word, Count: count, Distance: fm.Distance}
+			} else if fm.Distance == existing.Distance {
+				existing.Count += count
+				best[fm.Word] = existing
+			}
+		}
+	}
+
+	matches := make([]FuzzyMatch, 0, len(best))
+	for _, fm := range best {
+		matches = append(matches, fm)
+	}
+	return rankFuzzy(matches, k), true
+}
+
+// rankFuzzy sorts matches by ascending distance, then descending count, then
+// alphabetically for determinism, and truncates to k entries.
+func rankFuzzy(matches []FuzzyMatch, k int) []FuzzyMatch {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		if matches[i].Count != matches[j].Count {
+			return matches[i].Count > matches[j].Count
+		}
+		return strings.Compare(matches[i].Word, matches[j].Word) < 0
+	})
+	if k < len(matches) {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+/*
+	Purpose
+	Learn increments term's frequency by delta in the named corpus, so the
+	index can grow at runtime without re-parsing the whole file.
+
+	Parameters
+	corpusName - the corpus to update
+	term       - the term whose count should change
+	delta      - the amount to add to term's current count
+
+	Returns
+	false if corpusName isn't a loaded corpus.
+*/
+func (r *Registry) Learn(corpusName string, term string, delta int) bool {
+	r.mu.RLock()
+	c, ok := r.corpora[corpusName]
+	r.mu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	c.trie.Insert(term, delta)
+	return true
+}
+
+/*
+	Purpose
+	SoleCorpus returns the name of the registry's only loaded corpus, for
+	callers that want to default an unspecified ?corpus= to something
+	sensible when there's no ambiguity.
+
+	Returns
+	The sole corpus name and true, or "" and false if the registry holds
+	zero or more than one corpus.
+*/
+func (r *Registry) SoleCorpus() (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.order) != 1 {
+		return "", false
+	}
+	return r.order[0], true
+}
+
+/*
+	Purpose
+	List returns Info for every loaded corpus, in the order corpora were
+	added.
+*/
+func (r *Registry) List() []Info {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]Info, len(r.order))
+	for i, name := range r.order {
+		infos[i] = Info{Name: name, Weight: r.corpora[name].weight}
+	}
+	return infos
+}
+
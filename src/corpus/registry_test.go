@@ -0,0 +1,244 @@
+package corpus
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/emanuelbust/autocomplete/ngram"
+	"github.com/emanuelbust/autocomplete/prefixindex"
+)
+
+// newEntryData builds a trie and n-gram model from word->count pairs, for
+// registering as a test corpus.
+func newEntryData(counts map[string]int) (*prefixindex.Trie, *ngram.Model) {
+	trie := prefixindex.New()
+	for word, count := range counts {
+		trie.Insert(word, count)
+	}
+	return trie, ngram.New()
+}
+
+func completionScore(t *testing.T, completions []Completion, word string) float64 {
+	t.Helper()
+	for _, c := range completions {
+		if c.Word == word {
+			return c.Score
+		}
+	}
+	t.Fatalf("no completion for %q in %+v", word, completions)
+	return 0
+}
+
+func TestAddWeightZeroExcludesFromMergeButNotDirectQuery(t *testing.T) {
+	r := NewRegistry()
+	aTrie, aNgrams := newEntryData(map[string]int{"cat": 5})
+	bTrie, bNgrams := newEntryData(map[string]int{"cat": 100})
+	r.Add("a", aTrie, aNgrams, DefaultWeight)
+	r.Add("b", bTrie, bNgrams, 0)
+
+	merged, ok := r.Complete(context.Background(), "", "cat", nil, 10, ngram.DefaultLambdas())
+	if !ok {
+		t.Fatal("Complete(\"\") = false, want true")
+	}
+	if got := completionScore(t, merged, "cat"); got != 5 {
+		t.Errorf("merged score for %q = %v, want 5 (corpus b's weight-0 contribution excluded)", "cat", got)
+	}
+
+	direct, ok := r.Complete(context.Background(), "b", "cat", nil, 10, ngram.DefaultLambdas())
+	if !ok {
+		t.Fatal("Complete(\"b\") = false, want true")
+	}
+	if got := completionScore(t, direct, "cat"); got != 100 {
+		t.Errorf("direct score for %q in corpus b = %v, want 100 (weight-0 shouldn't block direct queries)", "cat", got)
+	}
+}
+
+func TestCompleteMergesWeightedAcrossCorpora(t *testing.T) {
+	r := NewRegistry()
+	aTrie, aNgrams := newEntryData(map[string]int{"cat": 10})
+	bTrie, bNgrams := newEntryData(map[string]int{"cat": 4})
+	r.Add("a", aTrie, aNgrams, 2)
+	r.Add("b", bTrie, bNgrams, 0.5)
+
+	completions, ok := r.Complete(context.Background(), "", "cat", nil, 10, ngram.DefaultLambdas())
+	if !ok {
+		t.Fatal("Complete(\"\") = false, want true")
+	}
+	// 10*2 + 4*0.5 = 22.
+	if got := completionScore(t, completions, "cat"); got != 22 {
+		t.Errorf("merged score for %q = %v, want 22", "cat", got)
+	}
+}
+
+func TestCompleteUnknownCorpusReturnsFalse(t *testing.T) {
+	r := NewRegistry()
+	trie, ngrams := newEntryData(map[string]int{"cat": 1})
+	r.Add("a", trie, ngrams, DefaultWeight)
+
+	if _, ok := r.Complete(context.Background(), "missing", "cat", nil, 10, ngram.DefaultLambdas()); ok {
+		t.Error("Complete(\"missing\") = true, want false")
+	}
+}
+
+func TestPredictWeightsAcrossCorpora(t *testing.T) {
+	r := NewRegistry()
+	aTrie, aNgrams := newEntryData(nil)
+	aNgrams.Add([]string{"the", "cat", "sat"})
+	bTrie, bNgrams := newEntryData(nil)
+	bNgrams.Add([]string{"the", "cat"})
+	r.Add("a", aTrie, aNgrams, 1)
+	r.Add("b", bTrie, bNgrams, 0)
+
+	lambdas := ngram.Lambdas{Unigram: 1, Bigram: 0, Trigram: 0}
+	merged, ok := r.Predict("", nil, 10, lambdas)
+	if !ok {
+		t.Fatal("Predict(\"\") = false, want true")
+	}
+	// Corpus b is weighted 0, so only corpus a's unigram distribution
+	// (1/3 each for the/cat/sat) should show up.
+	if got := completionScore(t, merged, "sat"); got == 0 {
+		t.Errorf("merged prediction for %q = %v, want a's nonzero contribution", "sat", got)
+	}
+	if got := completionScore(t, merged, "the"); got != 1.0/3.0 {
+		t.Errorf("merged prediction for %q = %v, want 1/3 (corpus b's weight-0 contribution excluded)", "the", got)
+	}
+
+	direct, ok := r.Predict("b", nil, 10, lambdas)
+	if !ok {
+		t.Fatal("Predict(\"b\") = false, want true")
+	}
+	if got := completionScore(t, direct, "the"); got != 0.5 {
+		t.Errorf("direct prediction for %q in corpus b = %v, want 0.5", "the", got)
+	}
+}
+
+func TestPredictUnknownCorpusReturnsFalse(t *testing.T) {
+	r := NewRegistry()
+	trie, ngrams := newEntryData(nil)
+	ngrams.Add([]string{"the", "cat"})
+	r.Add("a", trie, ngrams, DefaultWeight)
+
+	if _, ok := r.Predict("missing", []string{"the"}, 10, ngram.DefaultLambdas()); ok {
+		t.Error("Predict(\"missing\") = true, want false")
+	}
+}
+
+func TestFuzzyMergesAndWeighsAcrossCorpora(t *testing.T) {
+	r := NewRegistry()
+	aTrie, aNgrams := newEntryData(map[string]int{"cat": 10})
+	bTrie, bNgrams := newEntryData(map[string]int{"cat": 4})
+	r.Add("a", aTrie, aNgrams, 2)
+	r.Add("b", bTrie, bNgrams, 0.5)
+
+	merged, ok := r.Fuzzy(context.Background(), "", "cot", 1, false, 10)
+	if !ok {
+		t.Fatal("Fuzzy(\"\") = false, want true")
+	}
+	if len(merged) != 1 {
+		t.Fatalf("Fuzzy(\"\") = %+v, want a single merged match", merged)
+	}
+	// 10*2 + 4*0.5 = 22.
+	if merged[0].Count != 22 {
+		t.Errorf("merged fuzzy count for %q = %v, want 22", merged[0].Word, merged[0].Count)
+	}
+
+	direct, ok := r.Fuzzy(context.Background(), "b", "cot", 1, false, 10)
+	if !ok {
+		t.Fatal("Fuzzy(\"b\") = false, want true")
+	}
+	if len(direct) != 1 || direct[0].Count != 4 {
+		t.Errorf("direct fuzzy match in corpus b = %+v, want count 4 (unweighted)", direct)
+	}
+}
+
+func TestFuzzyUnknownCorpusReturnsFalse(t *testing.T) {
+	r := NewRegistry()
+	trie, ngrams := newEntryData(map[string]int{"cat": 1})
+	r.Add("a", trie, ngrams, DefaultWeight)
+
+	if _, ok := r.Fuzzy(context.Background(), "missing", "cat", 1, false, 10); ok {
+		t.Error("Fuzzy(\"missing\") = true, want false")
+	}
+}
+
+func TestLearnUnknownCorpusReturnsFalse(t *testing.T) {
+	r := NewRegistry()
+	trie, ngrams := newEntryData(map[string]int{"cat": 1})
+	r.Add("a", trie, ngrams, DefaultWeight)
+
+	if r.Learn("missing", "cat", 1) {
+		t.Error("Learn(\"missing\") = true, want false")
+	}
+}
+
+func TestLearnIncrementsCorpusCount(t *testing.T) {
+	r := NewRegistry()
+	trie, ngrams := newEntryData(map[string]int{"cat": 1})
+	r.Add("a", trie, ngrams, DefaultWeight)
+
+	if !r.Learn("a", "cat", 4) {
+		t.Fatal("Learn(\"a\") = false, want true")
+	}
+
+	completions, ok := r.Complete(context.Background(), "a", "cat", nil, 10, ngram.DefaultLambdas())
+	if !ok {
+		t.Fatal("Complete(\"a\") = false, want true")
+	}
+	if got := completionScore(t, completions, "cat"); got != 5 {
+		t.Errorf("score for %q after Learn = %v, want 5", "cat", got)
+	}
+}
+
+func TestSoleCorpus(t *testing.T) {
+	r := NewRegistry()
+	if _, ok := r.SoleCorpus(); ok {
+		t.Error("SoleCorpus() on an empty registry = true, want false")
+	}
+
+	trie, ngrams := newEntryData(map[string]int{"cat": 1})
+	r.Add("a", trie, ngrams, DefaultWeight)
+	if name, ok := r.SoleCorpus(); !ok || name != "a" {
+		t.Errorf("SoleCorpus() = (%q, %v), want (\"a\", true)", name, ok)
+	}
+
+	trie2, ngrams2 := newEntryData(map[string]int{"dog": 1})
+	r.Add("b", trie2, ngrams2, DefaultWeight)
+	if _, ok := r.SoleCorpus(); ok {
+		t.Error("SoleCorpus() with two corpora = true, want false")
+	}
+}
+
+func TestListPreservesInsertionOrderAndWeights(t *testing.T) {
+	r := NewRegistry()
+	aTrie, aNgrams := newEntryData(nil)
+	bTrie, bNgrams := newEntryData(nil)
+	r.Add("b", bTrie, bNgrams, 3)
+	r.Add("a", aTrie, aNgrams, 0)
+
+	want := []Info{{Name: "b", Weight: 3}, {Name: "a", Weight: 0}}
+	if got := r.List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAddReplacesExistingName(t *testing.T) {
+	r := NewRegistry()
+	trie1, ngrams1 := newEntryData(map[string]int{"cat": 1})
+	r.Add("a", trie1, ngrams1, DefaultWeight)
+
+	trie2, ngrams2 := newEntryData(map[string]int{"dog": 1})
+	r.Add("a", trie2, ngrams2, DefaultWeight)
+
+	if list := r.List(); len(list) != 1 {
+		t.Fatalf("List() = %+v, want exactly one entry after replacing \"a\"", list)
+	}
+
+	completions, ok := r.Complete(context.Background(), "a", "cat", nil, 10, ngram.DefaultLambdas())
+	if !ok {
+		t.Fatal("Complete(\"a\") = false, want true")
+	}
+	if len(completions) != 0 {
+		t.Errorf("Complete(\"a\", \"cat\") = %+v, want empty since \"a\" was replaced", completions)
+	}
+}
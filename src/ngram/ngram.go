@@ -0,0 +1,258 @@
+// Package ngram builds unigram/bigram/trigram frequency tables from a
+// corpus and uses them to rank or predict words given trailing context, via
+// Jelinek-Mercer smoothed interpolation of the three orders.
+package ngram
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+	Purpose
+	Lambdas weights how much the unigram, bigram and trigram models each
+	contribute to an interpolated score. They should sum to 1.
+*/
+type Lambdas struct {
+	Unigram float64
+	Bigram  float64
+	Trigram float64
+}
+
+// DefaultLambdas favors longer context, as is typical for Jelinek-Mercer
+// interpolation: the more specific a model, the more it's trusted once it
+// has seen enough data to be informative.
+func DefaultLambdas() Lambdas {
+	return Lambdas{Unigram: 0.1, Bigram: 0.3, Trigram: 0.6}
+}
+
+/*
+	Purpose
+	Scored pairs a word with its interpolated score.
+*/
+type Scored struct {
+	Word  string
+	Score float64
+}
+
+/*
+	Purpose
+	Model holds unigram, bigram and trigram frequency tables for a corpus
+	and answers interpolated-probability queries against them.
+
+	Model is safe for concurrent use.
+*/
+type Model struct {
+	mu sync.RWMutex
+
+	unigrams map[string]int
+	total    int
+
+	// bigrams[prev] counts words seen immediately after prev.
+	bigrams      map[string]map[string]int
+	bigramTotals map[string]int
+
+	// trigrams[prev2+" "+prev1] counts words seen immediately after the
+	// two-word sequence "prev2 prev1".
+	trigrams      map[string]map[string]int
+	trigramTotals map[string]int
+}
+
+// New returns an empty Model.
+func New() *Model {
+	return &Model{
+		unigrams:      make(map[string]int),
+		bigrams:       make(map[string]map[string]int),
+		bigramTotals:  make(map[string]int),
+		trigrams:      make(map[string]map[string]int),
+		trigramTotals: make(map[string]int),
+	}
+}
+
+/*
+	Purpose
+	Add ingests a sequence of words, updating the unigram, bigram and
+	trigram tables from each word and its immediately preceding one or two
+	words. This function is used to index an entire corpus as one
+	continuous sequence of words.
+
+	Parameters
+	words - the sequence of words to add
+*/
+func (m *Model) Add(words []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, word := range words {
+		m.unigrams[word]++
+		m.total++
+
+		if i >= 1 {
+			prev := words[i-1]
+			if m.bigrams[prev] == nil {
+				m.bigrams[prev] = make(map[string]int)
+			}
+			m.bigrams[prev][word]++
+			m.bigramTotals[prev]++
+		}
+
+		if i >= 2 {
+			key := trigramKey(words[i-2], words[i-1])
+			if m.trigrams[key] == nil {
+				m.trigrams[key] = make(map[string]int)
+			}
+			m.trigrams[key][word]++
+			m.trigramTotals[key]++
+		}
+	}
+}
+
+func trigramKey(prev2, prev1 string) string {
+	return prev2 + " " + prev1
+}
+
+// unigramProb returns P(word). Caller must hold m.mu.
+func (m *Model) unigramProb(word string) float64 {
+	if m.total == 0 {
+		return 0
+	}
+	return float64(m.unigrams[word]) / float64(m.total)
+}
+
+// bigramProb returns P(word|prev). Caller must hold m.mu.
+func (m *Model) bigramProb(prev, word string) float64 {
+	total := m.bigramTotals[prev]
+	if total == 0 {
+		return 0
+	}
+	return float64(m.bigrams[prev][word]) / float64(total)
+}
+
+// trigramProb returns P(word|prev2,prev1). Caller must hold m.mu.
+func (m *Model) trigramProb(prev2, prev1, word string) float64 {
+	key := trigramKey(prev2, prev1)
+	total := m.trigramTotals[key]
+	if total == 0 {
+		return 0
+	}
+	return float64(m.trigrams[key][word]) / float64(total)
+}
+
+/*
+	Purpose
+	Score computes the Jelinek-Mercer interpolated score of word following
+	context: lambdas.Unigram*P(word) + lambdas.Bigram*P(word|w-1) +
+	lambdas.Trigram*P(word|w-2,w-1). Bigram/trigram terms are omitted when
+	context is too short to supply them.
+
+	Parameters
+	word    - the candidate word to score
+	context - the words preceding word, oldest first
+	lambdas - the interpolation weights
+
+	Returns
+	The interpolated score.
+*/
+func (m *Model) Score(word string, context []string, lambdas Lambdas) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	score := lambdas.Unigram * m.unigramProb(word)
+
+	if len(context) >= 1 {
+		score += lambdas.Bigram * m.bigramProb(context[len(context)-1], word)
+	}
+	if len(context) >= 2 {
+		score += lambdas.Trigram * m.trigramProb(context[len(context)-2], context[len(context)-1], word)
+	}
+
+	return score
+}
+
+/*
+	Purpose
+	Scores computes the interpolated score of every word that could
+	plausibly follow context: every word seen after context's last word or
+	last two words, or (absent any bigram/trigram data) every known word.
+
+	Parameters
+	context - the words preceding the predicted word, oldest first
+	lambdas - the interpolation weights
+
+	Returns
+	A map of candidate word to interpolated score.
+*/
+func (m *Model) Scores(context []string, lambdas Lambdas) map[string]float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	candidates := make(map[string]bool)
+	if len(context) >= 1 {
+		for word := range m.bigrams[context[len(context)-1]] {
+			candidates[word] = true
+		}
+	}
+	if len(context) >= 2 {
+		key := trigramKey(context[len(context)-2], context[len(context)-1])
+		for word := range m.trigrams[key] {
+			candidates[word] = true
+		}
+	}
+	if len(candidates) == 0 {
+		for word := range m.unigrams {
+			candidates[word] = true
+		}
+	}
+
+	scores := make(map[string]float64, len(candidates))
+	for word := range candidates {
+		score := lambdas.Unigram * m.unigramProb(word)
+		if len(context) >= 1 {
+			score += lambdas.Bigram * m.bigramProb(context[len(context)-1], word)
+		}
+		if len(context) >= 2 {
+			score += lambdas.Trigram * m.trigramProb(context[len(context)-2], context[len(context)-1], word)
+		}
+		scores[word] = score
+	}
+	return scores
+}
+
+/*
+	Purpose
+	TopK returns the k highest-scoring words that could follow context,
+	regardless of any prefix, sorted by descending score.
+
+	Parameters
+	context - the words preceding the predicted word, oldest first
+	k       - the maximum number of predictions to return
+	lambdas - the interpolation weights
+
+	Returns
+	Up to k Scored entries, highest score first.
+*/
+func (m *Model) TopK(context []string, k int, lambdas Lambdas) []Scored {
+	return topK(m.Scores(context, lambdas), k)
+}
+
+// topK sorts a word->score map by descending score (ties broken
+// alphabetically for determinism) and truncates it to k entries.
+func topK(scores map[string]float64, k int) []Scored {
+	scored := make([]Scored, 0, len(scores))
+	for word, score := range scores {
+		scored = append(scored, Scored{Word: word, Score: score})
+	}
+
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return strings.Compare(scored[i].Word, scored[j].Word) < 0
+	})
+
+	if k < len(scored) {
+		scored = scored[:k]
+	}
+	return scored
+}
@@ -0,0 +1,167 @@
+package ngram
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < epsilon
+}
+
+// corpus is a small, hand-countable training sequence:
+//
+//	the cat sat on the mat
+//	the cat ran
+//
+// so unigram/bigram/trigram probabilities can be checked against counts
+// worked out by hand instead of trusting the model's own bookkeeping.
+var corpus = [][]string{
+	{"the", "cat", "sat", "on", "the", "mat"},
+	{"the", "cat", "ran"},
+}
+
+func newTestModel() *Model {
+	m := New()
+	for _, sentence := range corpus {
+		m.Add(sentence)
+	}
+	return m
+}
+
+func TestScoreUnigramOnly(t *testing.T) {
+	m := newTestModel()
+	lambdas := Lambdas{Unigram: 1, Bigram: 0, Trigram: 0}
+
+	// "the" appears 3 times out of 9 total tokens.
+	got := m.Score("the", nil, lambdas)
+	want := 3.0 / 9.0
+	if !almostEqual(got, want) {
+		t.Errorf("Score(%q, nil) = %v, want %v", "the", got, want)
+	}
+}
+
+func TestScoreOmitsBigramAndTrigramForShortContext(t *testing.T) {
+	m := newTestModel()
+	lambdas := Lambdas{Unigram: 0.2, Bigram: 0.3, Trigram: 0.5}
+
+	// With no context, only the unigram term contributes, even though
+	// lambdas assign weight to bigram/trigram.
+	got := m.Score("cat", nil, lambdas)
+	want := lambdas.Unigram * (2.0 / 9.0)
+	if !almostEqual(got, want) {
+		t.Errorf("Score(%q, nil) = %v, want %v (unigram term only)", "cat", got, want)
+	}
+
+	// With one word of context, the trigram term is still omitted.
+	got = m.Score("sat", []string{"cat"}, lambdas)
+	want = lambdas.Unigram*(1.0/9.0) + lambdas.Bigram*(1.0/2.0)
+	if !almostEqual(got, want) {
+		t.Errorf("Score(%q, [cat]) = %v, want %v (no trigram term)", "sat", got, want)
+	}
+}
+
+func TestScoreInterpolatesAllThreeOrders(t *testing.T) {
+	m := newTestModel()
+	lambdas := Lambdas{Unigram: 0.1, Bigram: 0.3, Trigram: 0.6}
+
+	// In "... on the mat", mat's preceding bigram is "the" and preceding
+	// trigram is "on the": P(mat) = 1/9, P(mat|the) = 1/3,
+	// P(mat|on,the) = 1/1.
+	got := m.Score("mat", []string{"on", "the"}, lambdas)
+	want := lambdas.Unigram*(1.0/9.0) + lambdas.Bigram*(1.0/3.0) + lambdas.Trigram*(1.0/1.0)
+	if !almostEqual(got, want) {
+		t.Errorf("Score(%q, [on the]) = %v, want %v", "mat", got, want)
+	}
+}
+
+func TestScoreUnseenWordOrContextIsZero(t *testing.T) {
+	m := newTestModel()
+	lambdas := DefaultLambdas()
+
+	if got := m.Score("dog", nil, lambdas); got != 0 {
+		t.Errorf("Score(%q, nil) = %v, want 0", "dog", got)
+	}
+	if got := m.Score("the", []string{"dog"}, lambdas); got != lambdas.Unigram*(3.0/9.0) {
+		t.Errorf("Score(%q, [dog]) = %v, want unigram term only", "the", got)
+	}
+}
+
+func TestScoresCandidatesFromBigramAndTrigramKeys(t *testing.T) {
+	m := newTestModel()
+	lambdas := DefaultLambdas()
+
+	// After "the", the corpus only ever saw "cat" and "mat": candidates
+	// shouldn't include the full unigram vocabulary.
+	got := m.Scores([]string{"the"}, lambdas)
+	want := map[string]bool{"cat": true, "mat": true}
+	if len(got) != len(want) {
+		t.Fatalf("Scores([the]) candidates = %v, want exactly %v", keys(got), want)
+	}
+	for word := range want {
+		if _, ok := got[word]; !ok {
+			t.Errorf("Scores([the]) missing candidate %q", word)
+		}
+	}
+}
+
+func TestScoresFallsBackToFullVocabularyWithoutContextData(t *testing.T) {
+	m := newTestModel()
+	lambdas := DefaultLambdas()
+
+	// "ran" was never followed by anything, so there's no bigram/trigram
+	// data for it: every known word becomes a candidate.
+	got := m.Scores([]string{"ran"}, lambdas)
+	wantWords := []string{"the", "cat", "sat", "on", "mat", "ran"}
+	if len(got) != len(wantWords) {
+		t.Fatalf("Scores([ran]) returned %d candidates, want %d (%v)", len(got), len(wantWords), wantWords)
+	}
+	for _, word := range wantWords {
+		if _, ok := got[word]; !ok {
+			t.Errorf("Scores([ran]) missing candidate %q", word)
+		}
+	}
+}
+
+func keys(m map[string]float64) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+func TestTopKOrdersByDescendingScoreThenWord(t *testing.T) {
+	m := New()
+	// "a" and "b" tie in frequency; "c" is strictly more frequent.
+	m.Add([]string{"c", "c", "a", "b"})
+	lambdas := Lambdas{Unigram: 1, Bigram: 0, Trigram: 0}
+
+	got := m.TopK(nil, 3, lambdas)
+	want := []Scored{
+		{Word: "c", Score: 2.0 / 4.0},
+		{Word: "a", Score: 1.0 / 4.0},
+		{Word: "b", Score: 1.0 / 4.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TopK(nil, 3) = %+v, want %+v", got, want)
+	}
+}
+
+func TestTopKTruncatesToK(t *testing.T) {
+	m := newTestModel()
+	lambdas := DefaultLambdas()
+
+	got := m.TopK(nil, 2, lambdas)
+	if len(got) != 2 {
+		t.Fatalf("TopK(nil, 2) returned %d entries, want 2", len(got))
+	}
+	if got[0].Score < got[1].Score {
+		t.Errorf("TopK(nil, 2) = %+v, not sorted by descending score", got)
+	}
+}
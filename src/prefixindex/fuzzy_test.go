@@ -0,0 +1,149 @@
+package prefixindex
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+// damerauLevenshtein is a reference implementation of Damerau-Levenshtein
+// edit distance between a and b, computed by the textbook full-matrix DP
+// rather than the trie's per-node row walk. When transpose is false,
+// adjacent-rune swaps cost two edits (a deletion plus an insertion) instead
+// of one, matching fuzzyWalk's non-transpose behavior.
+func damerauLevenshtein(a, b []rune, transpose bool) int {
+	rows, cols := len(a)+1, len(b)+1
+	d := make([][]int, rows)
+	for i := range d {
+		d[i] = make([]int, cols)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i < rows; i++ {
+		for j := 1; j < cols; j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			best := d[i-1][j] + 1 // delete
+			if ins := d[i][j-1] + 1; ins < best {
+				best = ins // insert
+			}
+			if sub := d[i-1][j-1] + cost; sub < best {
+				best = sub // match/substitute
+			}
+			if transpose && i >= 2 && j >= 2 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				if swap := d[i-2][j-2] + 1; swap < best {
+					best = swap // transpose
+				}
+			}
+
+			d[i][j] = best
+		}
+	}
+	return d[rows-1][cols-1]
+}
+
+// bruteForceFuzzy scans every word in vocab (a word->count map) with the
+// reference distance function, keeping those within maxDist of query. It's
+// the non-trie equivalent of Trie.Fuzzy, used to check the trie's pruned
+// DP walk doesn't drop or misscore any match.
+func bruteForceFuzzy(vocab map[string]int, query string, maxDist int, transpose bool) []FuzzyMatch {
+	queryRunes := []rune(query)
+	matches := make([]FuzzyMatch, 0)
+	for word, count := range vocab {
+		dist := damerauLevenshtein([]rune(word), queryRunes, transpose)
+		if dist <= maxDist {
+			matches = append(matches, FuzzyMatch{Word: word, Count: count, Distance: dist})
+		}
+	}
+	return matches
+}
+
+// sortFuzzy orders matches by (Distance, Word) for a stable comparison,
+// since Trie.Fuzzy itself makes no ordering promise.
+func sortFuzzy(matches []FuzzyMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Word < matches[j].Word
+	})
+}
+
+func TestTrieFuzzyMatchesReference(t *testing.T) {
+	vocab := map[string]int{
+		"cat": 5, "cats": 2, "bat": 3, "cot": 1, "dog": 4,
+		"cast": 2, "act": 1, "tac": 1, "catalog": 1,
+	}
+	trie := New()
+	for word, count := range vocab {
+		trie.Insert(word, count)
+	}
+
+	tests := []struct {
+		query     string
+		maxDist   int
+		transpose bool
+	}{
+		{"cat", 1, false},
+		{"cat", 2, false},
+		{"cat", 1, true},
+		{"act", 2, true},
+		{"xyz", 2, false},
+		{"ca", 0, false},
+	}
+
+	for _, tc := range tests {
+		got := trie.Fuzzy(context.Background(), tc.query, tc.maxDist, tc.transpose)
+		want := bruteForceFuzzy(vocab, tc.query, tc.maxDist, tc.transpose)
+		sortFuzzy(got)
+		sortFuzzy(want)
+
+		if len(got) != len(want) {
+			t.Errorf("Fuzzy(%q, %d, transpose=%v) = %+v, want %+v", tc.query, tc.maxDist, tc.transpose, got, want)
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("Fuzzy(%q, %d, transpose=%v)[%d] = %+v, want %+v", tc.query, tc.maxDist, tc.transpose, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+// TestTrieFuzzyTransposeCostsOneEdit checks that "ab" -> "ba" is distance 1
+// with transpose and distance 2 without, the behavior transpose exists for.
+func TestTrieFuzzyTransposeCostsOneEdit(t *testing.T) {
+	trie := New()
+	trie.Insert("ba", 1)
+
+	withTranspose := trie.Fuzzy(context.Background(), "ab", 1, true)
+	if len(withTranspose) != 1 || withTranspose[0].Distance != 1 {
+		t.Errorf("Fuzzy(%q, 1, transpose=true) = %+v, want a single match at distance 1", "ab", withTranspose)
+	}
+
+	withoutTranspose := trie.Fuzzy(context.Background(), "ab", 1, false)
+	if len(withoutTranspose) != 0 {
+		t.Errorf("Fuzzy(%q, 1, transpose=false) = %+v, want no matches within distance 1", "ab", withoutTranspose)
+	}
+}
+
+// TestTrieFuzzyCanceledContext checks that a canceled context stops the
+// DP walk early rather than always returning the full result.
+func TestTrieFuzzyCanceledContext(t *testing.T) {
+	trie := New()
+	trie.Insert("cat", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	got := trie.Fuzzy(ctx, "cat", 3, false)
+	if len(got) != 0 {
+		t.Errorf("Fuzzy with a canceled context = %+v, want no results collected", got)
+	}
+}
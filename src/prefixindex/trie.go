@@ -0,0 +1,332 @@
+// Package prefixindex provides a trie-backed index of word frequencies that
+// supports fast prefix lookups and incremental updates.
+package prefixindex
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// cacheWidth is how many of the highest-frequency completions are cached at
+// each node. TopK queries for k <= cacheWidth are served straight from the
+// cache; larger queries fall back to a full subtree walk.
+const cacheWidth = 25
+
+/*
+	Purpose
+	WordCount serves as a tuple for a word and how many times it's counted.
+	Ie the WordCount WordCount{"donkey", 12} means "donkey" appeared 12 times
+*/
+type WordCount struct {
+	Word  string
+	Count int
+}
+
+// node is a single rune of a trie path. count/isWord are only meaningful
+// when isWord is true, i.e. a word ends at this node.
+type node struct {
+	children map[rune]*node
+	isWord   bool
+	count    int
+
+	// top holds the cacheWidth highest-frequency words in this node's
+	// subtree (including the node itself, if isWord), sorted by
+	// descending count. It is recomputed whenever the subtree changes.
+	top []WordCount
+}
+
+func newNode() *node {
+	return &node{children: make(map[rune]*node)}
+}
+
+/*
+	Purpose
+	Trie is a prefix tree of words and their frequencies. Each node caches
+	the top completions of its subtree so that Prefix and TopK lookups don't
+	require scanning the entire vocabulary on every request.
+
+	Trie is safe for concurrent use.
+*/
+type Trie struct {
+	mu   sync.RWMutex
+	root *node
+}
+
+// New returns an empty Trie.
+func New() *Trie {
+	return &Trie{root: newNode()}
+}
+
+/*
+	Purpose
+	Insert adds delta to the frequency count of word, creating the word's
+	path in the trie if it doesn't already exist. delta may be negative to
+	decrement a count, but the count is floored at 0.
+
+	Parameters
+	word  - the word to update
+	delta - the amount to add to the word's current count
+*/
+func (t *Trie) Insert(word string, delta int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	path := make([]*node, 0, len(word)+1)
+	cur := t.root
+	path = append(path, cur)
+
+	for _, r := range word {
+		child, ok := cur.children[r]
+		if !ok {
+			child = newNode()
+			cur.children[r] = child
+		}
+		cur = child
+		path = append(path, cur)
+	}
+
+	cur.isWord = true
+	cur.count += delta
+	if cur.count < 0 {
+		cur.count = 0
+	}
+
+	// Bubble the cache update back up to the root.
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].recomputeTop()
+	}
+}
+
+// recomputeTop rebuilds n's cache of top completions from its own word (if
+// any) and its children's caches. Each cached WordCount.Word is relative to
+// n, i.e. the suffix still needed to reach the end of the word from n; the
+// caller prepends the matched prefix to get the full word.
+func (n *node) recomputeTop() {
+	candidates := make([]WordCount, 0, cacheWidth+1)
+	if n.isWord {
+		candidates = append(candidates, WordCount{Word: "", Count: n.count})
+	}
+	for r, child := range n.children {
+		for _, wc := range child.top {
+			candidates = append(candidates, WordCount{Word: string(r) + wc.Word, Count: wc.Count})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Count > candidates[j].Count
+	})
+	if len(candidates) > cacheWidth {
+		candidates = candidates[:cacheWidth]
+	}
+	n.top = candidates
+}
+
+/*
+	Purpose
+	Prefix returns every word in the trie that begins with prefix, along
+	with its frequency, sorted by descending count. Unlike TopK it is not
+	limited by the node cache width, so it walks the whole matching
+	subtree; ctx is checked periodically during that walk so a canceled
+	request can abort early instead of always paying for the full subtree.
+
+	Parameters
+	ctx    - governs early cancellation of the subtree walk
+	prefix - the prefix to search for
+
+	Returns
+	A slice of WordCount, most frequent first. If ctx is canceled partway
+	through, the result reflects only the portion of the subtree walked so
+	far.
+*/
+func (t *Trie) Prefix(ctx context.Context, prefix string) []WordCount {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n := t.find(prefix)
+	if n == nil {
+		return []WordCount{}
+	}
+
+	matches := make([]WordCount, 0)
+	collect(ctx, n, prefix, &matches)
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Count > matches[j].Count
+	})
+	return matches
+}
+
+/*
+	Purpose
+	TopK returns the k highest-frequency words beginning with prefix,
+	sorted by descending count. When k is within the cached width this is
+	served directly from the prefix node's cache in O(len(prefix)); larger
+	k falls back to Prefix.
+
+	Parameters
+	ctx    - governs early cancellation when k falls back to Prefix
+	prefix - the prefix to search for
+	k      - the maximum number of completions to return
+
+	Returns
+	Up to k WordCount entries, most frequent first.
+*/
+func (t *Trie) TopK(ctx context.Context, prefix string, k int) []WordCount {
+	if k > cacheWidth {
+		matches := t.Prefix(ctx, prefix)
+		if k < len(matches) {
+			return matches[:k]
+		}
+		return matches
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n := t.find(prefix)
+	if n == nil {
+		return []WordCount{}
+	}
+
+	top := n.top
+	if k < len(top) {
+		top = top[:k]
+	}
+	matches := make([]WordCount, len(top))
+	for i, wc := range top {
+		matches[i] = WordCount{Word: prefix + wc.Word, Count: wc.Count}
+	}
+	return matches
+}
+
+// find walks the trie to the node at the end of prefix, returning nil if no
+// such path exists.
+func (t *Trie) find(prefix string) *node {
+	cur := t.root
+	for _, r := range prefix {
+		child, ok := cur.children[r]
+		if !ok {
+			return nil
+		}
+		cur = child
+	}
+	return cur
+}
+
+// collect performs a full DFS of n's subtree, appending a WordCount for
+// every word found, with prefix prepended to the assembled suffix. It stops
+// descending as soon as ctx is canceled.
+func collect(ctx context.Context, n *node, prefix string, out *[]WordCount) {
+	if ctx.Err() != nil {
+		return
+	}
+	if n.isWord {
+		*out = append(*out, WordCount{Word: prefix, Count: n.count})
+	}
+	for r, child := range n.children {
+		collect(ctx, child, prefix+string(r), out)
+	}
+}
+
+/*
+	Purpose
+	FuzzyMatch pairs a word with its edit distance from a fuzzy query and
+	its frequency.
+*/
+type FuzzyMatch struct {
+	Word     string
+	Count    int
+	Distance int
+}
+
+/*
+	Purpose
+	Fuzzy returns every word in the trie within Damerau-Levenshtein
+	distance maxDist of query, with transpositions (adjacent-rune swaps)
+	counting as a single edit when transpose is true and as two edits
+	(a deletion plus an insertion) otherwise. Matching words are found by
+	walking the whole trie, maintaining the dynamic-programming edit
+	distance row for the path to each node and pruning any subtree whose
+	row minimum already exceeds maxDist, rather than scanning the
+	vocabulary. Unicode runes are compared, not bytes, so multibyte
+	characters aren't over-penalized.
+
+	Parameters
+	ctx       - governs early cancellation of the trie walk, checked once
+				per node since each node's DP row is itself O(len(query))
+				to compute
+	query     - the (possibly misspelled) word to match against
+	maxDist   - the maximum edit distance to allow
+	transpose - whether adjacent-rune swaps count as one edit instead of two
+
+	Returns
+	The matching words, in no particular order; callers typically sort by
+	(Distance, Count). If ctx is canceled partway through, the result
+	reflects only the portion of the trie walked so far.
+*/
+func (t *Trie) Fuzzy(ctx context.Context, query string, maxDist int, transpose bool) []FuzzyMatch {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	queryRunes := []rune(query)
+	row := make([]int, len(queryRunes)+1)
+	for i := range row {
+		row[i] = i
+	}
+
+	matches := make([]FuzzyMatch, 0)
+	fuzzyWalk(ctx, t.root, 0, "", queryRunes, row, nil, maxDist, transpose, &matches)
+	return matches
+}
+
+// fuzzyWalk visits every child of n, extending the DP row for the trie path
+// one rune at a time. letter is the rune on the edge leading into n (0 at
+// the root); word is the trie word assembled so far on the path from the
+// root to n; row is n's DP row; prevRow is n's parent's DP row, needed to
+// score a transposition (nil above the first level, where no transposition
+// is yet possible).
+func fuzzyWalk(ctx context.Context, n *node, letter rune, word string, query []rune, row []int, prevRow []int, maxDist int, transpose bool, matches *[]FuzzyMatch) {
+	if ctx.Err() != nil {
+		return
+	}
+	if n.isWord && row[len(query)] <= maxDist {
+		*matches = append(*matches, FuzzyMatch{Word: word, Count: n.count, Distance: row[len(query)]})
+	}
+
+	for childRune, child := range n.children {
+		childRow := make([]int, len(query)+1)
+		childRow[0] = row[0] + 1
+		rowMin := childRow[0]
+
+		for j := 1; j <= len(query); j++ {
+			cost := 1
+			if childRune == query[j-1] {
+				cost = 0
+			}
+
+			best := row[j] + 1 // delete the trie rune
+			if ins := childRow[j-1] + 1; ins < best {
+				best = ins // insert the query rune
+			}
+			if sub := row[j-1] + cost; sub < best {
+				best = sub // match/substitute
+			}
+			if transpose && j >= 2 && prevRow != nil && childRune == query[j-2] && letter == query[j-1] {
+				if swap := prevRow[j-2] + 1; swap < best {
+					best = swap
+				}
+			}
+
+			childRow[j] = best
+			if best < rowMin {
+				rowMin = best
+			}
+		}
+
+		if rowMin > maxDist {
+			continue
+		}
+		fuzzyWalk(ctx, child, childRune, word+string(childRune), query, childRow, row, maxDist, transpose, matches)
+	}
+}
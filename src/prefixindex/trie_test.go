@@ -0,0 +1,184 @@
+package prefixindex
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// naiveFreq is a reference implementation of the trie's prefix/top-k
+// behavior: a flat word->count map, scanned linearly on every query. Tests
+// insert the same words into both it and a Trie and assert the two agree.
+type naiveFreq map[string]int
+
+func (f naiveFreq) insert(word string, delta int) {
+	f[word] += delta
+	if f[word] < 0 {
+		f[word] = 0
+	}
+}
+
+// prefix returns every word in f beginning with prefix, sorted by
+// descending count then by word for determinism.
+func (f naiveFreq) prefix(prefix string) []WordCount {
+	matches := make([]WordCount, 0)
+	for word, count := range f {
+		if strings.HasPrefix(word, prefix) {
+			matches = append(matches, WordCount{Word: word, Count: count})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Count != matches[j].Count {
+			return matches[i].Count > matches[j].Count
+		}
+		return matches[i].Word < matches[j].Word
+	})
+	return matches
+}
+
+// sortedByCount asserts matches are sorted by descending count, the
+// contract every trie query promises, independent of any particular
+// ordering among ties.
+func sortedByCount(t *testing.T, matches []WordCount) {
+	t.Helper()
+	for i := 1; i < len(matches); i++ {
+		if matches[i-1].Count < matches[i].Count {
+			t.Fatalf("matches not sorted by descending count: %+v", matches)
+		}
+	}
+}
+
+// wordSet keys matches by word (keeping each word's count) so two WordCount
+// slices can be compared regardless of order, used when only the cached
+// top-k (not the full tie-break order) is expected to match the naive
+// reference.
+func wordSet(matches []WordCount) map[string]int {
+	set := make(map[string]int, len(matches))
+	for _, wc := range matches {
+		set[wc.Word] = wc.Count
+	}
+	return set
+}
+
+func TestTrieInsertAndPrefix(t *testing.T) {
+	words := []struct {
+		word  string
+		delta int
+	}{
+		{"cat", 3}, {"car", 5}, {"card", 1}, {"dog", 2}, {"cat", 2}, {"car", -1},
+	}
+
+	trie := New()
+	naive := make(naiveFreq)
+	for _, w := range words {
+		trie.Insert(w.word, w.delta)
+		naive.insert(w.word, w.delta)
+	}
+
+	got := trie.Prefix(context.Background(), "ca")
+	want := naive.prefix("ca")
+	sortedByCount(t, got)
+	if !reflect.DeepEqual(wordSet(got), wordSet(want)) {
+		t.Errorf("Prefix(%q) = %+v, want %+v", "ca", got, want)
+	}
+
+	if got := trie.Prefix(context.Background(), "zzz"); len(got) != 0 {
+		t.Errorf("Prefix(%q) = %+v, want empty", "zzz", got)
+	}
+}
+
+// TestTrieInsertFloorsAtZero checks that decrementing a word's count below
+// zero clamps to zero instead of going negative, and that the word still
+// shows up (with count 0) rather than disappearing from the trie.
+func TestTrieInsertFloorsAtZero(t *testing.T) {
+	trie := New()
+	trie.Insert("cat", 2)
+	trie.Insert("cat", -5)
+
+	got := trie.Prefix(context.Background(), "cat")
+	want := []WordCount{{Word: "cat", Count: 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Prefix(%q) = %+v, want %+v", "cat", got, want)
+	}
+}
+
+// TestTrieTopKWithinCacheWidth checks that TopK, served from the per-node
+// cache, agrees with a naive full scan when k is small enough to be
+// answered from the cache alone.
+func TestTrieTopKWithinCacheWidth(t *testing.T) {
+	trie := New()
+	naive := make(naiveFreq)
+	counts := map[string]int{"ant": 10, "antler": 3, "anteater": 7, "anthem": 1, "antique": 5}
+	for word, count := range counts {
+		trie.Insert(word, count)
+		naive.insert(word, count)
+	}
+
+	k := 3
+	got := trie.TopK(context.Background(), "ant", k)
+	want := naive.prefix("ant")[:k]
+	sortedByCount(t, got)
+	if !reflect.DeepEqual(wordSet(got), wordSet(want)) {
+		t.Errorf("TopK(%q, %d) = %+v, want %+v", "ant", k, got, want)
+	}
+}
+
+// TestTrieTopKFallsBackBeyondCacheWidth checks that requesting more results
+// than cacheWidth falls back to a full subtree walk instead of returning
+// only the cached entries, so no word beyond the cache is silently dropped.
+func TestTrieTopKFallsBackBeyondCacheWidth(t *testing.T) {
+	trie := New()
+	naive := make(naiveFreq)
+	for i := 0; i < cacheWidth+10; i++ {
+		word := "word" + string(rune('a'+i%26)) + string(rune('A'+i))
+		trie.Insert(word, i+1)
+		naive.insert(word, i+1)
+	}
+
+	k := cacheWidth + 5
+	got := trie.TopK(context.Background(), "word", k)
+	want := naive.prefix("word")
+	if len(want) > k {
+		want = want[:k]
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("TopK(%q, %d) returned %d entries, want %d", "word", k, len(got), len(want))
+	}
+	sortedByCount(t, got)
+	if !reflect.DeepEqual(wordSet(got), wordSet(want)) {
+		t.Errorf("TopK(%q, %d) = %+v, want %+v", "word", k, got, want)
+	}
+}
+
+// TestTrieTopKBeyondCacheWidthUsesAllAvailable checks the k > len(matches)
+// branch of TopK's fallback, where fewer words exist than were asked for.
+func TestTrieTopKBeyondCacheWidthUsesAllAvailable(t *testing.T) {
+	trie := New()
+	trie.Insert("solo", 4)
+
+	got := trie.TopK(context.Background(), "solo", cacheWidth+1)
+	want := []WordCount{{Word: "solo", Count: 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TopK(%q, %d) = %+v, want %+v", "solo", cacheWidth+1, got, want)
+	}
+}
+
+// TestTriePrefixCanceledContext checks that a canceled context stops the
+// subtree walk early rather than always returning the full result.
+func TestTriePrefixCanceledContext(t *testing.T) {
+	trie := New()
+	for i := 0; i < 50; i++ {
+		trie.Insert("word"+string(rune('a'+i%26))+string(rune('A'+i)), i+1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	got := trie.Prefix(ctx, "word")
+	if len(got) != 0 {
+		t.Errorf("Prefix with a canceled context = %+v, want no results collected", got)
+	}
+}
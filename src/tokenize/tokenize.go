@@ -0,0 +1,207 @@
+// Package tokenize turns raw corpus text (and query terms) into normalized
+// words. It is Unicode-aware so non-ASCII corpora (accented Latin script,
+// Cyrillic, CJK, ...) tokenize correctly, and supports optional stemming
+// and stopword filtering.
+package tokenize
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"unicode"
+)
+
+/*
+	Purpose
+	Tokenizer turns text into a normalized slice of words, and normalizes a
+	single query term the same way so prefix lookups match how the corpus
+	was indexed.
+*/
+type Tokenizer interface {
+	// Tokenize splits text into words, applying case folding and the
+	// configured stemming/stopword/min-length rules.
+	Tokenize(text string) []string
+
+	// NormalizeTerm case-folds (and, if enabled, stems) a single query
+	// term without applying stopword or min-length filtering, since a
+	// partial prefix typed by a user shouldn't be discarded outright.
+	NormalizeTerm(term string) string
+}
+
+/*
+	Purpose
+	Config controls how a Pipeline normalizes tokens.
+
+	Fields
+	Stem      - whether to apply suffix stemming to each token
+	Stopwords - a set of words to drop entirely during Tokenize
+	MinLength - tokens (in runes) shorter than this are dropped during
+				Tokenize
+*/
+type Config struct {
+	Stem      bool
+	Stopwords map[string]bool
+	MinLength int
+}
+
+// Pipeline is the default Tokenizer.
+type Pipeline struct {
+	cfg Config
+}
+
+// New returns a Pipeline that tokenizes according to cfg.
+func New(cfg Config) *Pipeline {
+	return &Pipeline{cfg: cfg}
+}
+
+/*
+	Purpose
+	Tokenize splits text into runs of letters and numbers (via
+	unicode.IsLetter/unicode.IsNumber, so runs of non-ASCII characters
+	aren't corrupted), lowercases each one, drops stopwords, and then
+	applies stemming and the minimum length rule from Config.
+
+	Parameters
+	text - the raw text to tokenize
+
+	Returns
+	The normalized words found in text, in order.
+*/
+func (p *Pipeline) Tokenize(text string) []string {
+	words := splitWords(text)
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if p.cfg.Stopwords != nil && p.cfg.Stopwords[word] {
+			continue
+		}
+		if p.cfg.Stem {
+			word = stem(word)
+		}
+		if len([]rune(word)) < p.cfg.MinLength {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+
+	return tokens
+}
+
+/*
+	Purpose
+	NormalizeTerm applies the same case folding and (optional) stemming as
+	Tokenize to a single query term, without stopword or min-length
+	filtering. Only the first word found in term is returned, since a
+	query term is expected to be a single (possibly partial) word.
+
+	Parameters
+	term - the raw query term to normalize
+
+	Returns
+	The normalized term, or "" if term contains no letters or numbers.
+*/
+func (p *Pipeline) NormalizeTerm(term string) string {
+	words := splitWords(term)
+	if len(words) == 0 {
+		return ""
+	}
+
+	word := words[0]
+	if p.cfg.Stem {
+		word = stem(word)
+	}
+	return word
+}
+
+// splitWords walks text rune by rune, collecting contiguous runs of letters
+// and numbers into lowercased words.
+func splitWords(text string) []string {
+	words := make([]string, 0)
+
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			current.WriteRune(unicode.ToLower(r))
+		} else {
+			flush()
+		}
+	}
+	flush()
+
+	return words
+}
+
+// suffixes are ordered so that each one is tried before any shorter suffix
+// it ends with, so e.g. "-ational" is preferred over the shorter "-al" when
+// both would apply.
+var stemSuffixes = []string{
+	"ational", "ization", "fulness", "ousness", "iveness",
+	"ing", "edly", "ness", "ment", "ions", "tion",
+	"ed", "es", "ly", "al", "s",
+}
+
+/*
+	Purpose
+	stem applies a lightweight suffix-stripping stemmer: the longest
+	matching suffix from stemSuffixes that leaves at least 3 runes is
+	removed, so short words aren't stemmed away entirely; if the longest
+	match would leave fewer, a shorter matching suffix is tried. This
+	isn't a full Porter/Snowball implementation, just enough to collapse
+	common inflections ("jumps", "jumping" -> "jump").
+
+	Parameters
+	word - the already-lowercased word to stem
+
+	Returns
+	The stemmed word.
+*/
+func stem(word string) string {
+	runes := []rune(word)
+	for _, suffix := range stemSuffixes {
+		suffixRunes := []rune(suffix)
+		if len(runes) <= len(suffixRunes) {
+			continue
+		}
+		if strings.HasSuffix(word, suffix) && len(runes)-len(suffixRunes) >= 3 {
+			return string(runes[:len(runes)-len(suffixRunes)])
+		}
+	}
+	return word
+}
+
+/*
+	Purpose
+	LoadStopwords reads a newline-delimited list of stopwords from path,
+	normalizing each one with splitWords so it matches the lowercased,
+	unstemmed words that Tokenize checks stopwords against.
+
+	Parameters
+	path - the path to the stopword file
+
+	Returns
+	A set of stopwords, and an error if the file couldn't be read.
+*/
+func LoadStopwords(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stopwords := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		for _, word := range splitWords(scanner.Text()) {
+			stopwords[word] = true
+		}
+	}
+
+	return stopwords, scanner.Err()
+}
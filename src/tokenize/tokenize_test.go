@@ -0,0 +1,179 @@
+package tokenize
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitWordsSplitsOnNonLetterNonNumber(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, world!", []string{"hello", "world"}},
+		{"foo123bar", []string{"foo123bar"}},
+		{"  leading  and  trailing  ", []string{"leading", "and", "trailing"}},
+		{"", nil},
+		{"...", nil},
+	}
+
+	for _, tc := range tests {
+		got := splitWords(tc.text)
+		if len(got) == 0 && len(tc.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("splitWords(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+// TestSplitWordsIsUnicodeAware checks that runs of non-ASCII letters
+// (Cyrillic, CJK) and digits are grouped by unicode.IsLetter/IsNumber, not
+// split on byte boundaries, and that mixed scripts/digits in one run stay
+// together.
+func TestSplitWordsIsUnicodeAware(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Привет мир", []string{"привет", "мир"}},
+		{"日本語 テスト", []string{"日本語", "テスト"}},
+		{"café", []string{"café"}},
+		{"abc123日本語", []string{"abc123日本語"}},
+		{"١٢٣", []string{"١٢٣"}}, // Arabic-Indic digits are unicode.IsNumber
+	}
+
+	for _, tc := range tests {
+		got := splitWords(tc.text)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("splitWords(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+// TestStemLongestSuffixFirst checks that when multiple suffixes in
+// stemSuffixes would apply, the longest one wins, e.g. "-ational" over the
+// shorter "-al".
+func TestStemLongestSuffixFirst(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"relational", "rel"},    // "-ational" (7) is tried before "-al" (2)
+		{"jumping", "jump"},      // "-ing"
+		{"jumps", "jump"},        // "-s"
+		{"happiness", "happi"},   // "-ness"
+		{"nonsense", "nonsense"}, // no listed suffix matches its ending
+	}
+
+	for _, tc := range tests {
+		if got := stem(tc.word); got != tc.want {
+			t.Errorf("stem(%q) = %q, want %q", tc.word, got, tc.want)
+		}
+	}
+}
+
+// TestStemFloorsAtThreeRunes checks that a suffix is only stripped if at
+// least 3 runes remain, so short words aren't stemmed away entirely.
+func TestStemFloorsAtThreeRunes(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"as", "as"},    // "-s" would leave "a" (1 rune): not stemmed
+		{"cats", "cat"}, // "-s" leaves "cat" (3 runes): stemmed
+		{"its", "its"},  // "-s" would leave "it" (2 runes): not stemmed
+		{"ads", "ads"},  // "-s" would leave "ad" (2 runes): not stemmed
+	}
+
+	for _, tc := range tests {
+		if got := stem(tc.word); got != tc.want {
+			t.Errorf("stem(%q) = %q, want %q", tc.word, got, tc.want)
+		}
+	}
+}
+
+func TestPipelineTokenizeAppliesStopwords(t *testing.T) {
+	p := New(Config{Stopwords: map[string]bool{"the": true, "a": true}})
+
+	got := p.Tokenize("The cat sat on a mat")
+	want := []string{"cat", "sat", "on", "mat"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize(...) = %v, want %v", got, want)
+	}
+}
+
+// TestPipelineTokenizeMinLengthAppliesAfterStemming checks that MinLength
+// filters on the post-stem word, so a word that's only long enough before
+// stemming is still dropped.
+func TestPipelineTokenizeMinLengthAppliesAfterStemming(t *testing.T) {
+	p := New(Config{Stem: true, MinLength: 4})
+
+	// "cats" (4 runes) stems to "cat" (3 runes), which should be dropped;
+	// "jumping" (7 runes) stems to "jump" (4 runes), which should survive.
+	got := p.Tokenize("cats jumping")
+	want := []string{"jump"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize(...) = %v, want %v", got, want)
+	}
+}
+
+func TestPipelineTokenizeWithoutStemmingIgnoresStemSuffixes(t *testing.T) {
+	p := New(Config{})
+
+	got := p.Tokenize("cats jumping")
+	want := []string{"cats", "jumping"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize(...) = %v, want %v", got, want)
+	}
+}
+
+func TestPipelineNormalizeTermReturnsFirstWordOnly(t *testing.T) {
+	p := New(Config{Stem: true})
+
+	if got := p.NormalizeTerm("Running fast"); got != "runn" {
+		t.Errorf("NormalizeTerm(%q) = %q, want %q", "Running fast", got, "runn")
+	}
+	if got := p.NormalizeTerm("   "); got != "" {
+		t.Errorf("NormalizeTerm(%q) = %q, want empty", "   ", got)
+	}
+}
+
+// TestPipelineNormalizeTermSkipsStopwordAndMinLengthRules checks that
+// NormalizeTerm doesn't drop a term even if it would be filtered by
+// Tokenize, since a user's partial prefix shouldn't be discarded outright.
+func TestPipelineNormalizeTermSkipsStopwordAndMinLengthRules(t *testing.T) {
+	p := New(Config{Stopwords: map[string]bool{"a": true}, MinLength: 5})
+
+	if got := p.NormalizeTerm("a"); got != "a" {
+		t.Errorf("NormalizeTerm(%q) = %q, want %q (no stopword/min-length filtering)", "a", got, "a")
+	}
+}
+
+func TestLoadStopwordsNormalizesEachLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "stopwords.txt")
+	contents := "The\nA, AN\n\nCafé\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	got, err := LoadStopwords(path)
+	if err != nil {
+		t.Fatalf("LoadStopwords: %v", err)
+	}
+
+	want := map[string]bool{"the": true, "a": true, "an": true, "café": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadStopwords(...) = %v, want %v", got, want)
+	}
+}
+
+func TestLoadStopwordsMissingFileReturnsError(t *testing.T) {
+	if _, err := LoadStopwords(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadStopwords on a missing file = nil error, want an error")
+	}
+}